@@ -0,0 +1,69 @@
+// Command myqmetrics polls a MySQL server's SHOW STATUS/VARIABLES (and, if
+// available, replication status) the same way the other myq-tools do, and
+// serves the resulting MyqState stream over HTTP: a Prometheus /metrics
+// scrape endpoint and an SSE/ndjson /state feed for dashboards.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/wfxiang08/myq-tools/myqlib"
+)
+
+func main() {
+	addr := flag.String("addr", ":9104", "address to serve /metrics and /state on")
+	dsn := flag.String("dsn", "", "MySQL DSN for the native database/sql loader, e.g. user:pass@tcp(host:3306)/; if empty, falls back to mysqladmin via -mysqladmin-args")
+	mysqladminArgs := flag.String("mysqladmin-args", "", `extra args passed to mysqladmin when -dsn is not given (e.g. "-h 127.0.0.1 -u root")`)
+	interval := flag.Duration("interval", 5*time.Second, "polling interval")
+	host := flag.String("host", "", "host label to attach to exported metrics (defaults to the local hostname)")
+	flag.Parse()
+
+	if *host == "" {
+		if hostname, err := os.Hostname(); err == nil {
+			*host = hostname
+		}
+	}
+
+	var loader myqlib.Loader
+	if *dsn != "" {
+		dbLoader, err := myqlib.NewDBLoader(*dsn, *interval)
+		if err != nil {
+			log.Fatal("connecting to MySQL: ", err)
+		}
+		loader = dbLoader
+	} else {
+		loader = myqlib.NewLiveLoader(*interval, *mysqladminArgs)
+	}
+
+	states, err := myqlib.GetState(loader)
+	if err != nil {
+		log.Fatal("starting loader: ", err)
+	}
+
+	metrics := myqlib.NewMetricsServer(states, *host)
+	server := &http.Server{Addr: *addr, Handler: metrics.Handler()}
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+	log.Println("myqmetrics listening on", *addr)
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGTERM, syscall.SIGINT)
+	<-sig
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Println("graceful shutdown failed: ", err)
+	}
+}
@@ -0,0 +1,18 @@
+package myqlib
+
+import "testing"
+
+func TestNormalizeKey(t *testing.T) {
+	cases := map[string]string{
+		"Uptime":                "uptime",
+		"Threads_connected":     "threads_connected",
+		"Seconds_Behind_Master": "seconds_behind_master",
+		"already_lower":         "already_lower",
+	}
+
+	for in, want := range cases {
+		if got := normalizeKey(in); got != want {
+			t.Errorf("normalizeKey(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -19,11 +19,14 @@ const (
 	VARIABLES_COMMAND MySQLAdminCommand = "variables"
 	// prefix of SHOW VARIABLES keys, they are stored (if available) in the same map as the status variables
 	VAR_PREFIX = "V_"
+	// prefix of replication status keys (SHOW SLAVE STATUS / SHOW REPLICA STATUS), folded into the same map
+	REPL_PREFIX = "R_"
 )
 
 type Loader interface {
 	getStatus() (chan MyqSample, error)
 	getVars() (chan MyqSample, error)
+	getReplication() (chan MyqSample, error)
 	getInterval() time.Duration
 }
 
@@ -126,6 +129,36 @@ func GetState(l Loader) (chan *MyqState, error) {
 		gotvars <- true
 	}
 
+	// Replication status, if the loader knows how to fetch it
+	replch, replerr := l.getReplication()
+	if replerr != nil && replerr != ErrNoReplicationSource {
+		// Serious error
+		return nil, replerr
+	}
+
+	var latestrepl MyqSample // whatever the last replication sample is will be here (may be empty)
+	gotrepl := make(chan bool, 1)
+
+	if replerr == nil {
+		// Only start up the latestrepl loop if there are no errors
+		go func() {
+			for repl := range replch {
+				latestrepl = repl
+				// gotrepl is only ever drained once, on the first status
+				// sample, so a blocking send here would stall this loop
+				// (and freeze latestrepl) the moment that one slot fills
+				// up a second time against a continuously-polling loader.
+				select {
+				case gotrepl <- true:
+				default:
+				}
+			}
+			gotrepl <- true
+		}()
+	} else {
+		gotrepl <- true
+	}
+
 	// Now getStatus
 	var ch = make(chan *MyqState)
 	statusch, statuserr := l.getStatus()
@@ -165,15 +198,21 @@ func GetState(l Loader) (chan *MyqState, error) {
 				}
 			}
 
-			// In the first loop iteration, wait for some vars to be loaded
+			// In the first loop iteration, wait for some vars and replication status to be loaded
 			if prev == nil {
 				<-gotvars
+				<-gotrepl
 			}
 			// Add latest vars to status with prefix
 			for k, v := range latestvars {
 				newkey := fmt.Sprint(VAR_PREFIX, k)
 				state.Cur[newkey] = v
 			}
+			// Add latest replication status to status with prefix
+			for k, v := range latestrepl {
+				newkey := fmt.Sprint(REPL_PREFIX, k)
+				state.Cur[newkey] = v
+			}
 
 			// Send the state
 			ch <- state
@@ -195,12 +234,13 @@ func (l loaderInterval) getInterval() time.Duration {
 // Load mysql status output from a mysqladmin output file
 type FileLoader struct {
 	loaderInterval
-	statusFile    string
-	variablesFile string
+	statusFile      string
+	variablesFile   string
+	replicationFile string
 }
 
-func NewFileLoader(i time.Duration, statusFile, varFile string) *FileLoader {
-	return &FileLoader{loaderInterval(i), statusFile, varFile}
+func NewFileLoader(i time.Duration, statusFile, varFile, replFile string) *FileLoader {
+	return &FileLoader{loaderInterval(i), statusFile, varFile, replFile}
 }
 func (l FileLoader) harvestFile(filename string) (chan MyqSample, error) {
 	file, err := os.OpenFile(filename, os.O_RDONLY, 0)
@@ -0,0 +1,273 @@
+package myqlib
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// MetricType is the Prometheus metric type a status/variable key should be
+// exported as.
+type MetricType string
+
+const (
+	CounterType MetricType = "counter"
+	GaugeType   MetricType = "gauge"
+)
+
+// MetricRegistry maps MyqSample keys (Com_select, Threads_running, ...) to
+// the Prometheus MetricType they should be exported as.  It's data-driven
+// rather than a switch statement so operators can extend it (AddPrefix,
+// AddExact) without recompiling core types; anything not matched falls back
+// to gauge, since most SHOW VARIABLES/replication fields are point-in-time
+// values rather than ever-increasing counters.
+type MetricRegistry struct {
+	exact    map[string]MetricType
+	prefixes []prefixRule
+	fallback MetricType
+}
+
+type prefixRule struct {
+	prefix string
+	typ    MetricType
+}
+
+func NewMetricRegistry() *MetricRegistry {
+	return &MetricRegistry{exact: make(map[string]MetricType), fallback: GaugeType}
+}
+
+// AddExact registers the type for a single, exact key.
+func (r *MetricRegistry) AddExact(key string, typ MetricType) {
+	r.exact[key] = typ
+}
+
+// AddPrefix registers the type for all keys starting with prefix. Rules are
+// checked in the order they were added; AddExact always wins over a prefix.
+func (r *MetricRegistry) AddPrefix(prefix string, typ MetricType) {
+	r.prefixes = append(r.prefixes, prefixRule{prefix, typ})
+}
+
+// TypeOf returns the metric type registered for key, or the registry's
+// fallback (gauge) if nothing matches.  Keys are matched case-insensitively,
+// since the loaders hand GetState lowercased keys (e.g. "com_select").
+func (r *MetricRegistry) TypeOf(key string) MetricType {
+	if typ, ok := r.exact[key]; ok {
+		return typ
+	}
+	for exact, typ := range r.exact {
+		if strings.EqualFold(exact, key) {
+			return typ
+		}
+	}
+	for _, rule := range r.prefixes {
+		if len(key) >= len(rule.prefix) && strings.EqualFold(key[:len(rule.prefix)], rule.prefix) {
+			return rule.typ
+		}
+	}
+	return r.fallback
+}
+
+// DefaultMetricRegistry classifies the common SHOW STATUS families:
+// Com_*/Handler_*/Innodb_*_ops-style counters count events since startup,
+// everything else (Threads_*, buffer pool occupancy, replication lag, ...)
+// is a gauge.
+func DefaultMetricRegistry() *MetricRegistry {
+	r := NewMetricRegistry()
+
+	for _, prefix := range []string{
+		"Com_", "Handler_", "Created_", "Select_", "Sort_", "Table_locks_",
+		"Qcache_", "Innodb_rows_", "Innodb_data_", "Innodb_log_",
+		"Innodb_buffer_pool_read_", "Innodb_buffer_pool_write_",
+		"Aborted_", "Binlog_cache_", "Key_", "Opened_", "Slow_",
+	} {
+		r.AddPrefix(prefix, CounterType)
+	}
+
+	for key, typ := range map[string]MetricType{
+		"Bytes_received": CounterType,
+		"Bytes_sent":     CounterType,
+		"Queries":        CounterType,
+		"Questions":      CounterType,
+		"Uptime":         CounterType,
+		"Connections":    CounterType,
+	} {
+		r.AddExact(key, typ)
+	}
+
+	return r
+}
+
+// MetricsServer keeps the latest MyqState available to HTTP handlers and
+// fans out subsequent states to any /state streaming clients.
+type MetricsServer struct {
+	host     string
+	registry *MetricRegistry
+
+	mu     sync.RWMutex
+	latest *MyqState
+
+	subMu sync.Mutex
+	subs  map[chan *MyqState]struct{}
+}
+
+// NewMetricsServer drains states in the background, keeping the most recent
+// sample (and relaying every sample to /state subscribers). host is
+// attached to every exported metric as a label.
+func NewMetricsServer(states chan *MyqState, host string) *MetricsServer {
+	m := &MetricsServer{
+		host:     host,
+		registry: DefaultMetricRegistry(),
+		subs:     make(map[chan *MyqState]struct{}),
+	}
+	go m.consume(states)
+	return m
+}
+
+func (m *MetricsServer) consume(states chan *MyqState) {
+	for state := range states {
+		m.mu.Lock()
+		m.latest = state
+		m.mu.Unlock()
+
+		m.subMu.Lock()
+		for sub := range m.subs {
+			select {
+			case sub <- state:
+			default: // slow subscriber, drop this sample rather than block the loader
+			}
+		}
+		m.subMu.Unlock()
+	}
+}
+
+// Handler returns an http.Handler serving /metrics (Prometheus text format)
+// and /state (an SSE or ndjson stream of samples, negotiated on Accept).
+func (m *MetricsServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", m.serveMetrics)
+	mux.HandleFunc("/state", m.serveState)
+	return mux
+}
+
+func (m *MetricsServer) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	m.mu.RLock()
+	state := m.latest
+	m.mu.RUnlock()
+
+	if state == nil {
+		http.Error(w, "no sample collected yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	labels := fmt.Sprintf(`host=%q,version=%q`, m.host, state.Cur.getStr(VAR_PREFIX+"version"))
+
+	keys := make([]string, 0, len(state.Cur))
+	for k := range state.Cur {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	declared := make(map[string]bool)
+	for _, key := range keys {
+		value, err := strconv.ParseFloat(state.Cur[key], 64)
+		if err != nil {
+			continue // non-numeric (strings like V_version, R_slave_io_running)
+		}
+
+		name := metricName(key)
+		typ := m.registry.TypeOf(key)
+		writeMetric(w, declared, name, typ, labels, value)
+
+		if typ == CounterType && state.Prev != nil && state.SecondsDiff > 0 {
+			if prevValue, err := strconv.ParseFloat(state.Prev[key], 64); err == nil {
+				rate := (value - prevValue) / state.SecondsDiff
+				writeMetric(w, declared, name+"_persec", GaugeType, labels, rate)
+			}
+		}
+	}
+}
+
+func writeMetric(w http.ResponseWriter, declared map[string]bool, name string, typ MetricType, labels string, value float64) {
+	if !declared[name] {
+		fmt.Fprintf(w, "# TYPE %s %s\n", name, typ)
+		declared[name] = true
+	}
+	fmt.Fprintf(w, "%s{%s} %s\n", name, labels, strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+// metricName turns a MyqSample key like "Com_select" or "V_max_connections"
+// into a Prometheus-legal name, e.g. "mysql_status_com_select".
+func metricName(key string) string {
+	var b strings.Builder
+	b.WriteString("mysql_status_")
+	for _, r := range strings.ToLower(key) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
+func (m *MetricsServer) serveState(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	sse := strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+	if sse {
+		w.Header().Set("Content-Type", "text/event-stream")
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	}
+	w.Header().Set("Cache-Control", "no-cache")
+
+	sub := make(chan *MyqState, 1)
+	m.subMu.Lock()
+	m.subs[sub] = struct{}{}
+	m.subMu.Unlock()
+	defer func() {
+		m.subMu.Lock()
+		delete(m.subs, sub)
+		m.subMu.Unlock()
+	}()
+
+	m.mu.RLock()
+	latest := m.latest
+	m.mu.RUnlock()
+	if latest != nil {
+		writeStateSample(w, sse, latest)
+		flusher.Flush()
+	}
+
+	for {
+		select {
+		case state := <-sub:
+			writeStateSample(w, sse, state)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func writeStateSample(w http.ResponseWriter, sse bool, state *MyqState) {
+	body, err := json.Marshal(state)
+	if err != nil {
+		return
+	}
+	if sse {
+		fmt.Fprintf(w, "data: %s\n\n", body)
+	} else {
+		fmt.Fprintf(w, "%s\n", body)
+	}
+}
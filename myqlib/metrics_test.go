@@ -0,0 +1,212 @@
+package myqlib
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricRegistryTypeOf(t *testing.T) {
+	r := NewMetricRegistry()
+	r.AddPrefix("Com_", CounterType)
+	r.AddExact("Threads_running", GaugeType)
+
+	cases := map[string]MetricType{
+		"Com_select":      CounterType,
+		"Threads_running": GaugeType,
+		"Unknown_key":     GaugeType, // falls back to the registry default
+	}
+
+	for key, want := range cases {
+		if got := r.TypeOf(key); got != want {
+			t.Errorf("TypeOf(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestDefaultMetricRegistry(t *testing.T) {
+	r := DefaultMetricRegistry()
+
+	cases := map[string]MetricType{
+		"Com_select":           CounterType,
+		"Handler_read_rnd":     CounterType,
+		"Queries":              CounterType,
+		"Uptime":               CounterType,
+		"Threads_running":      GaugeType,
+		"Innodb_row_lock_time": GaugeType,
+	}
+
+	for key, want := range cases {
+		if got := r.TypeOf(key); got != want {
+			t.Errorf("DefaultMetricRegistry().TypeOf(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestMetricName(t *testing.T) {
+	cases := map[string]string{
+		"Com_select":              "mysql_status_com_select",
+		"Threads_running":         "mysql_status_threads_running",
+		"V_max_connections":       "mysql_status_v_max_connections",
+		"R_seconds_behind_master": "mysql_status_r_seconds_behind_master",
+	}
+
+	for in, want := range cases {
+		if got := metricName(in); got != want {
+			t.Errorf("metricName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// fetchMetrics polls /metrics until the body contains want (NewMetricsServer
+// consumes its input channel asynchronously, so the sample isn't visible to
+// HTTP handlers the instant it's sent) or the deadline passes.
+func fetchMetrics(t *testing.T, baseURL, want string) string {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	var body string
+	for time.Now().Before(deadline) {
+		resp, err := http.Get(baseURL + "/metrics")
+		if err != nil {
+			t.Fatalf("GET /metrics: %v", err)
+		}
+		b, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("reading /metrics body: %v", err)
+		}
+		if resp.StatusCode == http.StatusOK && strings.Contains(string(b), want) {
+			return string(b)
+		}
+		body = string(b)
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Fatalf("/metrics never contained %q; last body:\n%s", want, body)
+	return ""
+}
+
+func TestMetricsServerServeMetrics(t *testing.T) {
+	states := make(chan *MyqState)
+	defer close(states)
+
+	m := NewMetricsServer(states, "testhost")
+	server := httptest.NewServer(m.Handler())
+	defer server.Close()
+
+	state1 := &MyqState{
+		Cur: MyqSample{
+			"Com_select":      "10",
+			"Threads_running": "3",
+			"V_version":       "8.0.1",
+			// Two distinct keys that sanitize to the same metric name:
+			// the TYPE line should only be declared once per response.
+			"Foo-Bar": "1",
+			"Foo_Bar": "2",
+		},
+	}
+	states <- state1
+
+	body := fetchMetrics(t, server.URL, "mysql_status_com_select")
+
+	for _, want := range []string{
+		"# TYPE mysql_status_com_select counter",
+		`mysql_status_com_select{host="testhost",version="8.0.1"} 10`,
+		"# TYPE mysql_status_threads_running gauge",
+		`mysql_status_threads_running{host="testhost",version="8.0.1"} 3`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body missing %q:\n%s", want, body)
+		}
+	}
+
+	if strings.Contains(body, "_persec") {
+		t.Errorf("didn't expect a _persec rate with no Prev sample yet:\n%s", body)
+	}
+
+	if got := strings.Count(body, "# TYPE mysql_status_foo_bar "); got != 1 {
+		t.Errorf("expected exactly one TYPE line for mysql_status_foo_bar (dedup), got %d:\n%s", got, body)
+	}
+	if got := strings.Count(body, "mysql_status_foo_bar{"); got != 2 {
+		t.Errorf("expected both Foo-Bar and Foo_Bar to still be exported as values, got %d:\n%s", got, body)
+	}
+
+	state2 := &MyqState{
+		Cur: MyqSample{
+			"Com_select":      "14",
+			"Threads_running": "5",
+			"V_version":       "8.0.1",
+		},
+		Prev:        state1.Cur,
+		SecondsDiff: 2,
+	}
+	states <- state2
+
+	body = fetchMetrics(t, server.URL, "mysql_status_com_select_persec")
+
+	for _, want := range []string{
+		"# TYPE mysql_status_com_select_persec gauge",
+		`mysql_status_com_select_persec{host="testhost",version="8.0.1"} 2`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body missing %q:\n%s", want, body)
+		}
+	}
+}
+
+// readOneStateFrame issues a /state request (negotiating SSE via the Accept
+// header when sse is true), reads just the first frame, and tears the
+// streaming request down.
+func readOneStateFrame(t *testing.T, baseURL string, sse bool) (contentType, frame string) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/state", nil)
+	if err != nil {
+		t.Fatalf("building /state request: %v", err)
+	}
+	if sse {
+		req.Header.Set("Accept", "text/event-stream")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /state: %v", err)
+	}
+	defer resp.Body.Close()
+
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil {
+		t.Fatalf("reading /state frame: %v", err)
+	}
+
+	return resp.Header.Get("Content-Type"), line
+}
+
+func TestMetricsServerServeState(t *testing.T) {
+	states := make(chan *MyqState)
+	defer close(states)
+
+	m := NewMetricsServer(states, "testhost")
+	server := httptest.NewServer(m.Handler())
+	defer server.Close()
+
+	states <- &MyqState{Cur: MyqSample{"Com_select": "10"}}
+	fetchMetrics(t, server.URL, "mysql_status_com_select") // wait for it to land
+
+	if ct, frame := readOneStateFrame(t, server.URL, false); !strings.HasPrefix(ct, "application/x-ndjson") || !strings.Contains(frame, `"Com_select":"10"`) {
+		t.Errorf("ndjson /state: content-type=%q frame=%q", ct, frame)
+	}
+
+	if ct, frame := readOneStateFrame(t, server.URL, true); ct != "text/event-stream" || !strings.HasPrefix(frame, "data: ") || !strings.Contains(frame, `"Com_select":"10"`) {
+		t.Errorf("SSE /state: content-type=%q frame=%q", ct, frame)
+	}
+}
@@ -0,0 +1,284 @@
+package myqlib
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	MYSQL_CLIENT         string = "mysql"
+	SLAVE_STATUS_QUERY   string = "SHOW SLAVE STATUS"
+	REPLICA_STATUS_QUERY string = "SHOW REPLICA STATUS"
+)
+
+// errNotAReplica is returned by a single poll when SHOW SLAVE/REPLICA
+// STATUS ran fine but came back empty, i.e. this server isn't a replica.
+// It's terminal for the polling loop (see getReplication below) rather
+// than a transient error to retry on: a standalone/master server isn't
+// going to grow replication status on the next tick.
+var errNotAReplica = errors.New("Not a replica (empty SHOW SLAVE/REPLICA STATUS)")
+
+// ErrNoReplicationSource means a Loader has no way to fetch replication
+// status at all right now (no file given, the mysql client isn't
+// installed, or the version/connectivity probe failed). GetState treats
+// this the same as a missing variables file: replication columns are
+// simply left unpopulated rather than aborting the whole pipeline.
+var ErrNoReplicationSource = errors.New("No replication source")
+
+// parseVerticalSample reads a single \G-style block, as produced by
+// `mysql -e "SHOW SLAVE STATUS\G"`, of the form:
+//
+//	*************************** 1. row ***************************
+//	          Key_name: value
+//	         Other_key: value
+//
+// into a MyqSample.  SHOW SLAVE STATUS/REPLICA STATUS only ever return (at
+// most) one row, so this stops at the first blank line after it.
+func parseVerticalSample(scanner *bufio.Scanner) (MyqSample, error) {
+	sample := make(MyqSample)
+	started := false
+
+	for scanner.Scan() {
+		trimmed := strings.TrimSpace(scanner.Text())
+
+		if trimmed == "" {
+			if started {
+				break
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "***") {
+			started = true
+			continue
+		}
+
+		idx := strings.Index(trimmed, ":")
+		if idx < 0 {
+			continue
+		}
+		key := normalizeKey(strings.TrimSpace(trimmed[:idx]))
+		sample[key] = strings.TrimSpace(trimmed[idx+1:])
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(sample) == 0 {
+		return nil, errNotAReplica
+	}
+
+	return sample, nil
+}
+
+// isMySQL8OrNewer parses the leading major version out of a VERSION()
+// string such as "8.0.22-log", to decide between SHOW SLAVE STATUS and
+// the MySQL 8 SHOW REPLICA STATUS rename. MariaDB reports its own major
+// version here (e.g. "10.5.9-MariaDB") but has never adopted the rename,
+// so it's excluded regardless of its version number.
+func isMySQL8OrNewer(version string) bool {
+	if strings.Contains(strings.ToLower(version), "mariadb") {
+		return false
+	}
+
+	major, err := strconv.Atoi(strings.SplitN(version, ".", 2)[0])
+	return err == nil && major >= 8
+}
+
+// getReplication reads a single captured "SHOW SLAVE STATUS\G" (or "SHOW
+// REPLICA STATUS\G") snapshot. Unlike getStatus, which replays a stream of
+// samples, this file only ever holds the one, so it's parsed once and fed
+// to GetState's latest-value loop, same as a FileLoader's variables file.
+func (l FileLoader) getReplication() (chan MyqSample, error) {
+	if l.replicationFile == "" {
+		return nil, ErrNoReplicationSource
+	}
+
+	file, err := os.OpenFile(l.replicationFile, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan MyqSample, 1)
+	go func() {
+		defer file.Close()
+		defer close(ch)
+
+		sample, err := parseVerticalSample(bufio.NewScanner(file))
+		if err != nil {
+			fmt.Println("parsing", l.replicationFile, "failed: ", err)
+			return
+		}
+		ch <- sample
+	}()
+
+	return ch, nil
+}
+
+// mysqlClientArgs builds the argument list for an ad-hoc `mysql` invocation,
+// reusing whatever connection args (-u, -p, -h, ...) were given to
+// NewLiveLoader for mysqladmin.
+func (l LiveLoader) mysqlClientArgs(extra ...string) []string {
+	args := append([]string{}, extra...)
+	if l.args != "" {
+		args = append(args, l.args)
+	}
+	return args
+}
+
+// getReplication auto-detects, once, whether the server understands the
+// MySQL 8 SHOW REPLICA STATUS rename or only the classic SHOW SLAVE STATUS,
+// then polls it via the `mysql` client on the same interval as getStatus.
+func (l LiveLoader) getReplication() (chan MyqSample, error) {
+	path, err := exec.LookPath(MYSQL_CLIENT)
+	if err != nil {
+		fmt.Println(MYSQL_CLIENT, "not available, skipping replication status: ", err)
+		return nil, ErrNoReplicationSource
+	}
+
+	statement, err := l.detectReplicationStatement(path)
+	if err != nil {
+		fmt.Println("detecting replication statement failed, skipping replication status: ", err)
+		return nil, ErrNoReplicationSource
+	}
+
+	ch := make(chan MyqSample)
+	go func() {
+		defer close(ch)
+
+		ticker := time.NewTicker(l.getInterval())
+		defer ticker.Stop()
+
+		for range ticker.C {
+			sample, err := l.runReplicationShow(path, statement)
+			if err == errNotAReplica {
+				// Not (or no longer) a replica: stop polling and close the
+				// channel so GetState's forwarder loop unblocks instead of
+				// waiting forever on a sample that will never arrive.
+				return
+			}
+			if err != nil {
+				fmt.Println(statement, "failed: ", err)
+				continue
+			}
+			ch <- sample
+		}
+	}()
+
+	return ch, nil
+}
+
+func (l LiveLoader) detectReplicationStatement(path string) (string, error) {
+	out, err := exec.Command(path, l.mysqlClientArgs("-N", "-e", "SELECT VERSION()")...).Output()
+	if err != nil {
+		return "", err
+	}
+
+	if isMySQL8OrNewer(strings.TrimSpace(string(out))) {
+		return REPLICA_STATUS_QUERY, nil
+	}
+	return SLAVE_STATUS_QUERY, nil
+}
+
+func (l LiveLoader) runReplicationShow(path, statement string) (MyqSample, error) {
+	cmd := exec.Command(path, l.mysqlClientArgs("-e", statement+`\G`)...)
+	cleanupSubcmd(cmd)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%v: %s", err, stderr.String())
+	}
+
+	return parseVerticalSample(bufio.NewScanner(bytes.NewReader(out)))
+}
+
+// getReplication auto-detects, once, whether the server understands SHOW
+// REPLICA STATUS or only SHOW SLAVE STATUS, then polls it on the same
+// connection as getStatus/getVars.
+func (l *DBLoader) getReplication() (chan MyqSample, error) {
+	statement, err := l.detectReplicationStatement()
+	if err != nil {
+		fmt.Println("detecting replication statement failed, skipping replication status: ", err)
+		return nil, ErrNoReplicationSource
+	}
+
+	ch := make(chan MyqSample)
+	go func() {
+		defer close(ch)
+
+		ticker := time.NewTicker(l.getInterval())
+		defer ticker.Stop()
+
+		for range ticker.C {
+			sample, err := l.runReplicationShow(statement)
+			if err == errNotAReplica {
+				// Not (or no longer) a replica: stop polling and close the
+				// channel so GetState's forwarder loop unblocks instead of
+				// waiting forever on a sample that will never arrive.
+				return
+			}
+			if err != nil {
+				fmt.Println(statement, "failed: ", err)
+				continue
+			}
+			ch <- sample
+		}
+	}()
+
+	return ch, nil
+}
+
+func (l *DBLoader) detectReplicationStatement() (string, error) {
+	var version string
+	if err := l.db.QueryRow("SELECT VERSION()").Scan(&version); err != nil {
+		return "", err
+	}
+
+	if isMySQL8OrNewer(version) {
+		return REPLICA_STATUS_QUERY, nil
+	}
+	return SLAVE_STATUS_QUERY, nil
+}
+
+func (l *DBLoader) runReplicationShow(statement string) (MyqSample, error) {
+	rows, err := l.db.Query(statement)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, errNotAReplica
+	}
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	raw := make([]sql.RawBytes, len(cols))
+	scanArgs := make([]interface{}, len(cols))
+	for i := range raw {
+		scanArgs[i] = &raw[i]
+	}
+	if err := rows.Scan(scanArgs...); err != nil {
+		return nil, err
+	}
+
+	sample := make(MyqSample, len(cols))
+	for i, col := range cols {
+		sample[normalizeKey(col)] = string(raw[i])
+	}
+
+	return sample, rows.Err()
+}
@@ -0,0 +1,60 @@
+package myqlib
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestParseVerticalSample(t *testing.T) {
+	input := `*************************** 1. row ***************************
+               Slave_IO_State: Waiting for master to send event
+                  Master_Host: 10.0.0.1
+          Seconds_Behind_Master: 0
+              Slave_IO_Running: Yes
+             Slave_SQL_Running: Yes
+                   Last_Errno: 0
+            Retrieved_Gtid_Set:
+             Executed_Gtid_Set:
+
+`
+	sample, err := parseVerticalSample(bufio.NewScanner(strings.NewReader(input)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]string{
+		"seconds_behind_master": "0",
+		"slave_io_running":      "Yes",
+		"slave_sql_running":     "Yes",
+		"last_errno":            "0",
+	}
+	for k, v := range want {
+		if sample[k] != v {
+			t.Errorf("sample[%q] = %q, want %q", k, sample[k], v)
+		}
+	}
+}
+
+func TestParseVerticalSampleEmpty(t *testing.T) {
+	_, err := parseVerticalSample(bufio.NewScanner(strings.NewReader("")))
+	if err != errNotAReplica {
+		t.Fatalf("expected errNotAReplica for an empty result, got %v", err)
+	}
+}
+
+func TestIsMySQL8OrNewer(t *testing.T) {
+	cases := map[string]bool{
+		"8.0.22-log":      true,
+		"8.4.0":           true,
+		"5.7.34-log":      false,
+		"10.5.9-MariaDB":  false,
+		"10.11.6-MariaDB": false,
+	}
+
+	for version, want := range cases {
+		if got := isMySQL8OrNewer(version); got != want {
+			t.Errorf("isMySQL8OrNewer(%q) = %v, want %v", version, got, want)
+		}
+	}
+}